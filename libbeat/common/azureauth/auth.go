@@ -0,0 +1,138 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !aix
+
+// Package azureauth builds azidentity credentials from a shared
+// configuration block, so every Azure input/output plugin (the
+// azureeventhub filebeat input and the azureeventhub output, so far)
+// authenticates the same way instead of each maintaining its own copy of
+// this resolution logic.
+package azureauth
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+// Config is the auth block shared by Azure plugins: connection-string/SAS
+// auth by default, or an azidentity credential when Type is set.
+type Config struct {
+	Type               string `config:"type"`
+	TenantID           string `config:"tenant_id"`
+	ClientID           string `config:"client_id"`
+	ClientSecret       string `config:"client_secret"`
+	FederatedTokenFile string `config:"federated_token_file"`
+}
+
+const (
+	TypeConnectionString = "connection_string"
+	TypeClientSecret     = "client_secret"
+	TypeManagedIdentity  = "managed_identity"
+	TypeWorkloadIdentity = "workload_identity"
+	TypeDefault          = "default"
+)
+
+// EffectiveType returns the configured auth type, defaulting to
+// connection-string/shared-key based authentication when unset.
+func (c Config) EffectiveType() string {
+	if c.Type == "" {
+		return TypeConnectionString
+	}
+	return c.Type
+}
+
+// UsesAzureAD reports whether Config selects an azidentity credential
+// instead of a SAS connection string / shared key.
+func (c Config) UsesAzureAD() bool {
+	return c.EffectiveType() != TypeConnectionString
+}
+
+// clouds maps the override_environment resource manager endpoints already
+// accepted for the legacy azure.Environment lookup to their
+// azcore/cloud.Configuration equivalents, so sovereign clouds work for both
+// SAS/shared-key and AAD auth.
+var clouds = map[string]cloud.Configuration{
+	"":                                      cloud.AzurePublic,
+	"https://management.chinacloudapi.cn/":  cloud.AzureChina,
+	"https://management.microsoftazure.de/": cloud.AzurePublic, // German cloud was retired; kept for config compatibility
+	"https://management.azure.com/":         cloud.AzurePublic,
+	"https://management.usgovcloudapi.net/": cloud.AzureGovernment,
+}
+
+// CloudConfiguration resolves the azcore cloud.Configuration for the given
+// override_environment resource manager endpoint, defaulting to the public
+// cloud.
+func CloudConfiguration(overrideResourceManager string) (cloud.Configuration, error) {
+	if overrideResourceManager == "" || overrideResourceManager == "<no value>" {
+		return cloud.AzurePublic, nil
+	}
+	if conf, ok := clouds[overrideResourceManager]; ok {
+		return conf, nil
+	}
+	// fall back to the resource manager endpoint itself; azidentity only
+	// needs the active directory authority host and the resource manager
+	// audience, both derived from the same environment as the legacy lookup.
+	env, err := azure.EnvironmentFromURL(overrideResourceManager)
+	if err != nil {
+		return cloud.Configuration{}, err
+	}
+	return cloud.Configuration{
+		ActiveDirectoryAuthorityHost: env.ActiveDirectoryEndpoint,
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {Endpoint: env.ResourceManagerEndpoint, Audience: env.TokenAudience},
+		},
+	}, nil
+}
+
+// NewCredential builds an azcore.TokenCredential from config, resolving the
+// correct AAD endpoint for sovereign clouds from overrideEnvironment.
+func NewCredential(config Config, overrideEnvironment string) (azcore.TokenCredential, error) {
+	cloudConf, err := CloudConfiguration(overrideEnvironment)
+	if err != nil {
+		return nil, err
+	}
+	clientOptions := azcore.ClientOptions{Cloud: cloudConf}
+
+	switch config.EffectiveType() {
+	case TypeClientSecret:
+		return azidentity.NewClientSecretCredential(
+			config.TenantID, config.ClientID, config.ClientSecret,
+			&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions})
+	case TypeManagedIdentity:
+		opts := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+		if config.ClientID != "" {
+			opts.ID = azidentity.ClientID(config.ClientID)
+		}
+		return azidentity.NewManagedIdentityCredential(opts)
+	case TypeWorkloadIdentity:
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions: clientOptions,
+			TenantID:      config.TenantID,
+			ClientID:      config.ClientID,
+			TokenFilePath: config.FederatedTokenFile,
+		})
+	case TypeDefault:
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{ClientOptions: clientOptions})
+	default:
+		return nil, fmt.Errorf("auth.type %q does not use an azidentity credential", config.EffectiveType())
+	}
+}
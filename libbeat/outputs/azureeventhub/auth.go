@@ -0,0 +1,34 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+
+	"github.com/elastic/beats/v7/libbeat/common/azureauth"
+)
+
+// newAzureCredential builds an azcore.TokenCredential from the auth block,
+// resolving the correct AAD endpoint for sovereign clouds from
+// override_environment. Shared with the azureeventhub input via
+// libbeat/common/azureauth so the two never drift.
+func newAzureCredential(auth azureauth.Config, overrideEnvironment string) (azcore.TokenCredential, error) {
+	return azureauth.NewCredential(auth, overrideEnvironment)
+}
@@ -0,0 +1,59 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !aix
+
+// Package azureeventhub implements a Beats output that publishes events to
+// an Azure Event Hub, using the same connection_string/AAD auth options as
+// the azureeventhub filebeat input.
+package azureeventhub
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func init() {
+	outputs.RegisterType("azureeventhub", makeAzureEventHub)
+}
+
+func makeAzureEventHub(
+	_ outputs.IndexManager,
+	_ beat.Info,
+	observer outputs.Observer,
+	cfg *conf.C,
+) (outputs.Group, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return outputs.Fail(err)
+	}
+	if err := config.Validate(); err != nil {
+		return outputs.Fail(err)
+	}
+
+	log := logp.NewLogger("azureeventhub")
+	client, err := newClient(log, observer, config)
+	if err != nil {
+		return outputs.Fail(fmt.Errorf("error creating azureeventhub client: %w", err))
+	}
+
+	return outputs.Success(config.BulkMaxSize, 0, client)
+}
@@ -0,0 +1,222 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+
+	"github.com/elastic/beats/v7/libbeat/common/azureauth"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const eventHubConnector = ";EntityPath="
+
+// client publishes Beats events to an Event Hub using a ProducerClient,
+// batching events with EventDataBatch to avoid a send per event.
+type client struct {
+	log      *logp.Logger
+	observer outputs.Observer
+	config   config
+
+	producer *azeventhubs.ProducerClient
+}
+
+func newClient(log *logp.Logger, observer outputs.Observer, config config) (*client, error) {
+	producer, err := newProducerClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &client{log: log, observer: observer, config: config, producer: producer}, nil
+}
+
+func newProducerClient(config config) (*azeventhubs.ProducerClient, error) {
+	if config.Auth.EffectiveType() != azureauth.TypeConnectionString {
+		cred, err := newAzureCredential(config.Auth, config.OverrideEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		return azeventhubs.NewProducerClient(config.FullyQualifiedNamespace, config.EventHubName, cred, nil)
+	}
+
+	connectionString := config.ConnectionString
+	if config.EventHubName != "" {
+		connectionString = fmt.Sprintf("%s%s%s", config.ConnectionString, eventHubConnector, config.EventHubName)
+	}
+	return azeventhubs.NewProducerClientFromConnectionString(connectionString, config.EventHubName, nil)
+}
+
+func (c *client) String() string {
+	return "azureeventhub(" + c.config.EventHubName + ")"
+}
+
+// Connect is a no-op: the ProducerClient connects lazily on first use, and
+// azeventhubs.NewProducerClient(FromConnectionString) already validated the
+// configuration when the client was constructed.
+func (c *client) Connect(_ context.Context) error {
+	return nil
+}
+
+func (c *client) Close() error {
+	return c.producer.Close(context.Background())
+}
+
+// Publish groups the batch's events by partition key, sends one or more
+// EventDataBatch per group (splitting when max_batch_bytes is exceeded), and
+// only ACKs the batch once every EventDataBatch has been accepted by Event
+// Hubs, giving the output the same at-least-once semantics as other Beats
+// outputs.
+func (c *client) Publish(ctx context.Context, batch publisher.Batch) error {
+	events := batch.Events()
+	c.observer.NewBatch(len(events))
+
+	grouped := make(map[string][]publisher.Event)
+	var order []string
+	for _, event := range events {
+		key := c.partitionKey(event)
+		if _, ok := grouped[key]; !ok {
+			order = append(order, key)
+		}
+		grouped[key] = append(grouped[key], event)
+	}
+
+	var dropped []publisher.Event
+	for _, key := range order {
+		failed, err := c.sendGroup(ctx, key, grouped[key])
+		if err != nil {
+			c.log.Errorw("error sending events to event hub", "partition_key", key, "error", err)
+		}
+		dropped = append(dropped, failed...)
+	}
+
+	if len(dropped) > 0 {
+		if acked := len(events) - len(dropped); acked > 0 {
+			c.observer.Acked(acked)
+		}
+		c.observer.Failed(len(dropped))
+		batch.RetryEvents(dropped)
+		return nil
+	}
+
+	c.observer.Acked(len(events))
+	batch.ACK()
+	return nil
+}
+
+// sendGroup sends every event sharing a partition key as one or more
+// EventDataBatch, returning the events whose batch failed to send so the
+// caller can retry them. Events that cannot be encoded, or that do not fit
+// even in an empty batch, are logged and dropped rather than returned.
+func (c *client) sendGroup(ctx context.Context, partitionKey string, group []publisher.Event) ([]publisher.Event, error) {
+	batchOptions := &azeventhubs.EventDataBatchOptions{MaxBytes: uint64(c.config.MaxBatchBytes)}
+	if partitionKey != "" {
+		batchOptions.PartitionKey = &partitionKey
+	}
+
+	var failed []publisher.Event
+	eventBatch, err := c.producer.NewEventDataBatch(ctx, batchOptions)
+	if err != nil {
+		return group, err
+	}
+	// batchStart is the index into group of the first event added to
+	// eventBatch since it was last flushed, so a flush failure only fails
+	// the events still sitting in that batch, not every event already
+	// flushed successfully earlier in the group.
+	batchStart := 0
+
+	flush := func() error {
+		if eventBatch.NumEvents() == 0 {
+			return nil
+		}
+		return c.producer.SendEventDataBatch(ctx, eventBatch, nil)
+	}
+
+	for i, event := range group {
+		data, err := encodeEvent(event)
+		if err != nil {
+			c.log.Warnw("dropping event that could not be encoded", "error", err)
+			continue
+		}
+
+		err = eventBatch.AddEventData(data, nil)
+		if err != nil {
+			// the current batch is full; flush it and start a new one
+			// for this and the remaining events in the group.
+			if flushErr := flush(); flushErr != nil {
+				failed = append(failed, group[batchStart:i]...)
+				return append(failed, group[i:]...), flushErr
+			}
+			eventBatch, err = c.producer.NewEventDataBatch(ctx, batchOptions)
+			if err != nil {
+				return append(failed, group[i:]...), err
+			}
+			batchStart = i
+			if err := eventBatch.AddEventData(data, nil); err != nil {
+				c.log.Warnw("dropping event too large for an empty batch", "error", err)
+				continue
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return append(failed, group[batchStart:]...), err
+	}
+	return failed, nil
+}
+
+// partitionKey evaluates config.PartitionKey against the event, falling
+// back to round-robin distribution (empty key) when unset or when the
+// format string cannot be resolved for this event.
+func (c *client) partitionKey(event publisher.Event) string {
+	if c.config.PartitionKey == nil {
+		return ""
+	}
+	key, err := c.config.PartitionKey.RunEvent(&event.Content)
+	if err != nil {
+		c.log.Debugw("error evaluating partition_key", "error", err)
+		return ""
+	}
+	return key
+}
+
+// encodeEvent serializes an event's "message" field if present (matching
+// how other line-oriented Beats outputs forward raw log lines), otherwise
+// the full event body, as JSON.
+func encodeEvent(event publisher.Event) (*azeventhubs.EventData, error) {
+	var body []byte
+	var err error
+	if message, msgErr := event.Content.Fields.GetValue("message"); msgErr == nil {
+		if s, ok := message.(string); ok {
+			body = []byte(s)
+		}
+	}
+	if body == nil {
+		body, err = json.Marshal(event.Content.Fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &azeventhubs.EventData{Body: body}, nil
+}
@@ -0,0 +1,118 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestClientPartitionKey(t *testing.T) {
+	event := publisher.Event{Content: beat.Event{Fields: mapstr.M{
+		"host": mapstr.M{"name": "myhost"},
+	}}}
+
+	t.Run("unset falls back to round robin", func(t *testing.T) {
+		c := &client{log: logp.NewLogger("azureeventhub"), config: config{}}
+		if got := c.partitionKey(event); got != "" {
+			t.Errorf("partitionKey() = %q, want empty", got)
+		}
+	})
+
+	t.Run("resolves configured format string", func(t *testing.T) {
+		fs, err := fmtstr.CompileEvent("%{[host.name]}")
+		if err != nil {
+			t.Fatalf("CompileEvent() error = %v", err)
+		}
+		c := &client{log: logp.NewLogger("azureeventhub"), config: config{PartitionKey: fs}}
+		if got := c.partitionKey(event); got != "myhost" {
+			t.Errorf("partitionKey() = %q, want %q", got, "myhost")
+		}
+	})
+
+	t.Run("unresolvable format string falls back to round robin", func(t *testing.T) {
+		fs, err := fmtstr.CompileEvent("%{[does.not.exist]}")
+		if err != nil {
+			t.Fatalf("CompileEvent() error = %v", err)
+		}
+		c := &client{log: logp.NewLogger("azureeventhub"), config: config{PartitionKey: fs}}
+		if got := c.partitionKey(event); got != "" {
+			t.Errorf("partitionKey() = %q, want empty", got)
+		}
+	})
+}
+
+func TestEncodeEvent(t *testing.T) {
+	t.Run("uses message field verbatim when present", func(t *testing.T) {
+		event := publisher.Event{Content: beat.Event{Fields: mapstr.M{
+			"message": "raw log line",
+			"host":    "myhost",
+		}}}
+
+		data, err := encodeEvent(event)
+		if err != nil {
+			t.Fatalf("encodeEvent() error = %v", err)
+		}
+		if string(data.Body) != "raw log line" {
+			t.Errorf("Body = %q, want %q", data.Body, "raw log line")
+		}
+	})
+
+	t.Run("falls back to JSON encoded fields when message is absent", func(t *testing.T) {
+		event := publisher.Event{Content: beat.Event{Fields: mapstr.M{
+			"host": "myhost",
+		}}}
+
+		data, err := encodeEvent(event)
+		if err != nil {
+			t.Fatalf("encodeEvent() error = %v", err)
+		}
+
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(data.Body, &decoded); err != nil {
+			t.Fatalf("Body is not valid JSON: %v", err)
+		}
+		if decoded["host"] != "myhost" {
+			t.Errorf("decoded[\"host\"] = %v, want %q", decoded["host"], "myhost")
+		}
+	})
+
+	t.Run("falls back to JSON when message field is not a string", func(t *testing.T) {
+		event := publisher.Event{Content: beat.Event{Fields: mapstr.M{
+			"message": 42,
+		}}}
+
+		data, err := encodeEvent(event)
+		if err != nil {
+			t.Fatalf("encodeEvent() error = %v", err)
+		}
+
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(data.Body, &decoded); err != nil {
+			t.Fatalf("Body is not valid JSON: %v", err)
+		}
+	})
+}
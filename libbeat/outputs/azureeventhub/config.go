@@ -0,0 +1,77 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/common/azureauth"
+	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
+)
+
+// config holds the user configurable options for the azureeventhub output.
+// Auth mirrors the auth block of the azureeventhub input (x-pack/filebeat/input/azureeventhub)
+// so operators configure AAD/managed identity the same way for both sides
+// of an Event Hub.
+type config struct {
+	ConnectionString        string `config:"connection_string"`
+	EventHubName            string `config:"eventhub"`
+	FullyQualifiedNamespace string `config:"fully_qualified_namespace"`
+	OverrideEnvironment     string `config:"override_environment"`
+
+	Auth azureauth.Config `config:"auth"`
+
+	// PartitionKey is a format string (e.g. "%{[host.name]}") evaluated
+	// against each event to choose its Event Hubs partition key. When empty,
+	// events are distributed round-robin across partitions.
+	PartitionKey *fmtstr.EventFormatString `config:"partition_key"`
+
+	// MaxBatchBytes caps the size of each EventDataBatch sent to Event Hubs,
+	// keeping batches under the service's per-request size limit while
+	// avoiding a round trip per event.
+	MaxBatchBytes int `config:"max_batch_bytes" validate:"min=1"`
+
+	// BulkMaxSize is the maximum number of events the publisher pipeline
+	// batches together before handing them to Publish, matching the
+	// bulk_max_size option of other Beats outputs. It is unrelated to
+	// MaxBatchBytes, which bounds the byte size of the EventDataBatch(es)
+	// Publish sends to Event Hubs for that batch.
+	BulkMaxSize int `config:"bulk_max_size" validate:"min=1"`
+}
+
+func defaultConfig() config {
+	return config{
+		MaxBatchBytes: 1024 * 1024,
+		BulkMaxSize:   2048,
+	}
+}
+
+func (c *config) Validate() error {
+	if c.EventHubName == "" {
+		return fmt.Errorf("eventhub is required")
+	}
+	if c.Auth.EffectiveType() == azureauth.TypeConnectionString && c.ConnectionString == "" {
+		return fmt.Errorf("connection_string is required unless auth.type is set")
+	}
+	if c.Auth.EffectiveType() != azureauth.TypeConnectionString && c.FullyQualifiedNamespace == "" {
+		return fmt.Errorf("fully_qualified_namespace is required when auth.type is set")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eventhubAuth "github.com/Azure/azure-event-hubs-go/v3/aad"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/elastic/beats/v7/libbeat/common/azureauth"
+)
+
+// newAzureCredential builds an azcore.TokenCredential from the auth block,
+// resolving the correct AAD endpoint for sovereign clouds from
+// override_environment. It is used for both the Event Hubs connection and
+// the Blob Storage checkpoint store, so the two always authenticate the
+// same way, and shares its resolution logic with the azureeventhub output
+// via libbeat/common/azureauth.
+func (a *azureInput) newAzureCredential() (azcore.TokenCredential, error) {
+	return azureauth.NewCredential(a.config.Auth, a.config.OverrideEnvironment)
+}
+
+// usesAzureADAuth reports whether the input should authenticate with an
+// azidentity credential instead of a SAS connection string / shared key.
+func (a *azureInput) usesAzureADAuth() bool {
+	return a.config.Auth.UsesAzureAD()
+}
+
+// newBlobCredential returns the legacy azblob.Credential used by the EPH
+// storage leaser/checkpointer: a shared-key credential by default, or a
+// token credential refreshed from the same azidentity credential used for
+// the Event Hubs connection when auth is configured.
+func (a *azureInput) newBlobCredential() (azblob.Credential, error) {
+	if !a.usesAzureADAuth() {
+		return azblob.NewSharedKeyCredential(a.config.SAName, a.config.SAKey)
+	}
+
+	tokenCred, err := a.newAzureCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := func(credential azblob.TokenCredential) time.Duration {
+		token, err := tokenCred.GetToken(context.Background(), policy.TokenRequestOptions{
+			Scopes: []string{"https://storage.azure.com/.default"},
+		})
+		if err != nil {
+			a.log.Errorw("error refreshing storage token", "error", err)
+			return time.Minute
+		}
+		credential.SetToken(token.Token)
+		return time.Until(token.ExpiresOn) / 2
+	}
+
+	initial, err := tokenCred.GetToken(context.Background(), policy.TokenRequestOptions{
+		Scopes: []string{"https://storage.azure.com/.default"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return azblob.NewTokenCredential(initial.Token, refresh), nil
+}
+
+// newEventHubTokenProvider adapts the azidentity credential to the
+// eventhubAuth.TokenProvider interface expected by the legacy
+// azure-event-hubs-go/v3 EPH scheduler.
+func (a *azureInput) newEventHubTokenProvider() (eventhubAuth.TokenProvider, error) {
+	cred, err := a.newAzureCredential()
+	if err != nil {
+		return nil, err
+	}
+	return &azcoreTokenProviderAdapter{credential: cred}, nil
+}
+
+// azcoreTokenProviderAdapter wraps an azcore.TokenCredential so it can be
+// used as the eventhubAuth.TokenProvider the legacy EPH scheduler asks for
+// on every connection/reconnection.
+type azcoreTokenProviderAdapter struct {
+	credential azcore.TokenCredential
+}
+
+func (p *azcoreTokenProviderAdapter) GetToken(uri string) (*eventhubAuth.Token, error) {
+	token, err := p.credential.GetToken(context.Background(), policy.TokenRequestOptions{
+		Scopes: []string{uri + "/.default"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return eventhubAuth.NewToken(eventhubAuth.CBSTokenTypeJWT, token.Token, fmt.Sprintf("%d", token.ExpiresOn.Unix())), nil
+}
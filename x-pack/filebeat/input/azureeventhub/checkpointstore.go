@@ -0,0 +1,63 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs/checkpoints"
+)
+
+// CheckpointStore is the partition ownership and checkpoint persistence
+// contract required by the azeventhubs.Processor. It mirrors
+// azeventhubs.CheckpointStore so that any implementation here can be handed
+// straight to azeventhubs.NewProcessor, and lets the azureeventhub input
+// support backends other than Azure Blob Storage (see checkpointstore_kubernetes.go).
+type CheckpointStore interface {
+	ClaimOwnership(ctx context.Context, partitionOwnership []azeventhubs.Ownership, options *azeventhubs.ClaimOwnershipOptions) ([]azeventhubs.Ownership, error)
+	ListCheckpoints(ctx context.Context, fullyQualifiedNamespace, eventHubName, consumerGroup string, options *azeventhubs.ListCheckpointsOptions) ([]azeventhubs.Checkpoint, error)
+	ListOwnership(ctx context.Context, fullyQualifiedNamespace, eventHubName, consumerGroup string, options *azeventhubs.ListOwnershipOptions) ([]azeventhubs.Ownership, error)
+	UpdateCheckpoint(ctx context.Context, checkpoint azeventhubs.Checkpoint, options *azeventhubs.UpdateCheckpointOptions) error
+}
+
+// newCheckpointStore builds the CheckpointStore selected by
+// checkpoint_store, defaulting to the Azure Blob Storage backed store that
+// has always backed the azeventhubs consumer client.
+func (a *azureInput) newCheckpointStore() (CheckpointStore, error) {
+	switch a.config.effectiveCheckpointStore() {
+	case checkpointStoreKubernetes:
+		return a.newKubernetesCheckpointStore()
+	case checkpointStoreBlob:
+		return a.newBlobCheckpointStoreImpl()
+	default:
+		return nil, fmt.Errorf("checkpoint_store %q is not supported", a.config.effectiveCheckpointStore())
+	}
+}
+
+// blobCheckpointStore adapts checkpoints.BlobStore to the CheckpointStore
+// interface; checkpoints.BlobStore already implements every method the
+// interface requires, so this only exists to give the type a name local to
+// the package.
+type blobCheckpointStore struct {
+	*checkpoints.BlobStore
+}
+
+// newBlobCheckpointStoreImpl builds the blob-backed CheckpointStore, reusing
+// the same container client construction as the legacy azeventhubs path.
+func (a *azureInput) newBlobCheckpointStoreImpl() (CheckpointStore, error) {
+	containerClient, err := a.newBlobContainerClient()
+	if err != nil {
+		return nil, err
+	}
+	blobStore, err := checkpoints.NewBlobStore(containerClient, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &blobCheckpointStore{BlobStore: blobStore}, nil
+}
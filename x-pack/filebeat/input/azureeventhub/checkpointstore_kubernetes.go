@@ -0,0 +1,267 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// checkpointAnnotation holds the checkpoint fields the input actually reads
+// back, serialized into the Lease's annotations. Leases have no
+// general-purpose data field, so ownership (holderIdentity) and checkpoint
+// progress both live on the same object per partition.
+type checkpointAnnotation struct {
+	SequenceNumber int64  `json:"sequenceNumber"`
+	Offset         string `json:"offset"`
+}
+
+const checkpointAnnotationKey = "azureeventhub.elastic.co/checkpoint"
+
+// kubernetesCheckpointStore implements CheckpointStore on top of
+// coordination.k8s.io/v1 Leases: claiming a partition acquires the Lease
+// (the same primitive kube-scheduler and controller-manager use for leader
+// election), and the checkpoint is stored as a JSON annotation on that
+// Lease. This lets Filebeat run the azureeventhub input on Kubernetes
+// without provisioning a storage account for partition distribution.
+type kubernetesCheckpointStore struct {
+	log       partitionLogger
+	client    kubernetes.Interface
+	namespace string
+	prefix    string
+	// identity identifies this process as a Lease holder; partitions owned
+	// by a different holderIdentity are only reclaimed once their Lease
+	// expires.
+	identity string
+}
+
+// newKubernetesCheckpointStore builds a kubernetesCheckpointStore using the
+// in-cluster service account, defaulting the namespace to the pod's own
+// namespace when kubernetes.namespace is unset.
+func (a *azureInput) newKubernetesCheckpointStore() (CheckpointStore, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint_store: kubernetes requires running in-cluster: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := a.config.Kubernetes.Namespace
+	if namespace == "" {
+		namespace, err = podNamespace()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	return &kubernetesCheckpointStore{
+		log:       a.log,
+		client:    clientset,
+		namespace: namespace,
+		prefix:    a.config.Kubernetes.LeasePrefix,
+		identity:  hostname,
+	}, nil
+}
+
+func podNamespace() (string, error) {
+	b, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if err != nil {
+		return "", fmt.Errorf("checkpoint_store: kubernetes.namespace is unset and the pod namespace could not be read: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (s *kubernetesCheckpointStore) leaseName(partitionID string) string {
+	return fmt.Sprintf("%s%s", s.prefix, partitionID)
+}
+
+// ListOwnership returns the current holder of every partition's Lease that
+// already exists; partitions without a Lease yet are omitted, matching the
+// contract of a fresh checkpoint store.
+func (s *kubernetesCheckpointStore) ListOwnership(ctx context.Context, fullyQualifiedNamespace, eventHubName, consumerGroup string, options *azeventhubs.ListOwnershipOptions) ([]azeventhubs.Ownership, error) {
+	leases, err := s.client.CoordinationV1().Leases(s.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var ownerships []azeventhubs.Ownership
+	for _, lease := range leases.Items {
+		if !strings.HasPrefix(lease.Name, s.prefix) {
+			continue
+		}
+		if lease.Spec.HolderIdentity == nil {
+			continue
+		}
+		ownerships = append(ownerships, azeventhubs.Ownership{
+			FullyQualifiedNamespace: fullyQualifiedNamespace,
+			EventHubName:            eventHubName,
+			ConsumerGroup:           consumerGroup,
+			PartitionID:             strings.TrimPrefix(lease.Name, s.prefix),
+			OwnerID:                 *lease.Spec.HolderIdentity,
+			ETag:                    lease.ResourceVersion,
+			LastModifiedTime:        leaseLastModifiedTime(&lease),
+		})
+	}
+	return ownerships, nil
+}
+
+// leaseLastModifiedTime returns the last time the Lease's holder renewed it,
+// falling back to acquisition/creation time for a Lease that was just
+// created and never renewed. Ownership.LastModifiedTime drives the
+// Processor's staleness check, so it must track renewals, not creation.
+func leaseLastModifiedTime(lease *coordinationv1.Lease) time.Time {
+	if lease.Spec.RenewTime != nil {
+		return lease.Spec.RenewTime.Time
+	}
+	if lease.Spec.AcquireTime != nil {
+		return lease.Spec.AcquireTime.Time
+	}
+	return lease.CreationTimestamp.Time
+}
+
+// ClaimOwnership attempts to acquire the Lease backing each requested
+// partition, creating it if needed and only stealing it from another holder
+// once its Kubernetes lease duration has elapsed.
+func (s *kubernetesCheckpointStore) ClaimOwnership(ctx context.Context, partitionOwnership []azeventhubs.Ownership, options *azeventhubs.ClaimOwnershipOptions) ([]azeventhubs.Ownership, error) {
+	var claimed []azeventhubs.Ownership
+	for _, want := range partitionOwnership {
+		lease, err := s.claimLease(ctx, want)
+		if err != nil {
+			if s.log != nil {
+				s.log.Errorw("checkpoint store: error claiming partition lease", "partition", want.PartitionID, "error", err)
+			}
+			continue
+		}
+		want.OwnerID = s.identity
+		want.ETag = lease.ResourceVersion
+		want.LastModifiedTime = leaseLastModifiedTime(lease)
+		claimed = append(claimed, want)
+	}
+	return claimed, nil
+}
+
+func (s *kubernetesCheckpointStore) claimLease(ctx context.Context, want azeventhubs.Ownership) (*coordinationv1.Lease, error) {
+	leases := s.client.CoordinationV1().Leases(s.namespace)
+	name := s.leaseName(want.PartitionID)
+
+	existing, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		durationSeconds := int32(leaseDurationSeconds)
+		now := metav1.NowMicro()
+		return leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &s.identity,
+				LeaseDurationSeconds: &durationSeconds,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.Spec.HolderIdentity != nil && *existing.Spec.HolderIdentity != s.identity && !leaseExpired(existing) {
+		return nil, fmt.Errorf("partition %s is owned by %s", want.PartitionID, *existing.Spec.HolderIdentity)
+	}
+
+	now := metav1.NowMicro()
+	existing.Spec.HolderIdentity = &s.identity
+	existing.Spec.RenewTime = &now
+	return leases.Update(ctx, existing, metav1.UpdateOptions{})
+}
+
+func leaseExpired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return time.Since(lease.Spec.RenewTime.Time) > time.Duration(*lease.Spec.LeaseDurationSeconds)*time.Second
+}
+
+// ListCheckpoints reads back the checkpoint annotation of every Lease this
+// store owns or can see.
+func (s *kubernetesCheckpointStore) ListCheckpoints(ctx context.Context, fullyQualifiedNamespace, eventHubName, consumerGroup string, options *azeventhubs.ListCheckpointsOptions) ([]azeventhubs.Checkpoint, error) {
+	leases, err := s.client.CoordinationV1().Leases(s.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoints []azeventhubs.Checkpoint
+	for _, lease := range leases.Items {
+		if !strings.HasPrefix(lease.Name, s.prefix) {
+			continue
+		}
+		raw, ok := lease.Annotations[checkpointAnnotationKey]
+		if !ok {
+			continue
+		}
+		var cp checkpointAnnotation
+		if err := json.Unmarshal([]byte(raw), &cp); err != nil {
+			continue
+		}
+		checkpoints = append(checkpoints, azeventhubs.Checkpoint{
+			FullyQualifiedNamespace: fullyQualifiedNamespace,
+			EventHubName:            eventHubName,
+			ConsumerGroup:           consumerGroup,
+			PartitionID:             strings.TrimPrefix(lease.Name, s.prefix),
+			SequenceNumber:          &cp.SequenceNumber,
+			Offset:                  &cp.Offset,
+		})
+	}
+	return checkpoints, nil
+}
+
+// UpdateCheckpoint persists the checkpoint as an annotation on the
+// partition's Lease.
+func (s *kubernetesCheckpointStore) UpdateCheckpoint(ctx context.Context, checkpoint azeventhubs.Checkpoint, options *azeventhubs.UpdateCheckpointOptions) error {
+	return s.setCheckpoint(ctx, checkpoint)
+}
+
+func (s *kubernetesCheckpointStore) setCheckpoint(ctx context.Context, checkpoint azeventhubs.Checkpoint) error {
+	leases := s.client.CoordinationV1().Leases(s.namespace)
+	name := s.leaseName(checkpoint.PartitionID)
+
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	var cp checkpointAnnotation
+	if checkpoint.SequenceNumber != nil {
+		cp.SequenceNumber = *checkpoint.SequenceNumber
+	}
+	if checkpoint.Offset != nil {
+		cp.Offset = *checkpoint.Offset
+	}
+	raw, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	lease.Annotations[checkpointAnnotationKey] = string(raw)
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+const leaseDurationSeconds = 30
@@ -0,0 +1,184 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func holderIdentity(id string) *string { return &id }
+
+func newLease(name, holder string, renewTime *metav1.MicroTime) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity: holderIdentity(holder),
+			RenewTime:      renewTime,
+		},
+	}
+}
+
+func TestKubernetesCheckpointStoreListOwnershipFiltersByPrefix(t *testing.T) {
+	renewTime := metav1.NewMicroTime(time.Now())
+	client := fake.NewSimpleClientset(
+		newLease("myinput-0", "pod-a", &renewTime),
+		newLease("myinput-1", "pod-a", &renewTime),
+		newLease("otherinput-0", "pod-b", &renewTime),
+	)
+
+	store := &kubernetesCheckpointStore{
+		client:    client,
+		namespace: "default",
+		prefix:    "myinput-",
+	}
+
+	ownerships, err := store.ListOwnership(context.Background(), "ns.servicebus.windows.net", "hub", "$Default", nil)
+	if err != nil {
+		t.Fatalf("ListOwnership() error = %v", err)
+	}
+
+	if len(ownerships) != 2 {
+		t.Fatalf("len(ownerships) = %d, want 2", len(ownerships))
+	}
+	seen := map[string]bool{}
+	for _, o := range ownerships {
+		seen[o.PartitionID] = true
+		if o.OwnerID != "pod-a" {
+			t.Errorf("ownership %+v has OwnerID %q, want pod-a", o, o.OwnerID)
+		}
+	}
+	if !seen["0"] || !seen["1"] {
+		t.Errorf("expected partitions 0 and 1, got %v", ownerships)
+	}
+}
+
+func TestKubernetesCheckpointStoreListCheckpointsFiltersByPrefix(t *testing.T) {
+	withCheckpoint := newLease("myinput-0", "pod-a", nil)
+	withCheckpoint.Annotations = map[string]string{
+		checkpointAnnotationKey: `{"sequenceNumber":42,"offset":"100"}`,
+	}
+	client := fake.NewSimpleClientset(
+		withCheckpoint,
+		newLease("otherinput-0", "pod-b", nil),
+	)
+
+	store := &kubernetesCheckpointStore{
+		client:    client,
+		namespace: "default",
+		prefix:    "myinput-",
+	}
+
+	checkpoints, err := store.ListCheckpoints(context.Background(), "ns.servicebus.windows.net", "hub", "$Default", nil)
+	if err != nil {
+		t.Fatalf("ListCheckpoints() error = %v", err)
+	}
+
+	if len(checkpoints) != 1 {
+		t.Fatalf("len(checkpoints) = %d, want 1", len(checkpoints))
+	}
+	if checkpoints[0].PartitionID != "0" {
+		t.Errorf("PartitionID = %q, want %q", checkpoints[0].PartitionID, "0")
+	}
+	if *checkpoints[0].SequenceNumber != 42 {
+		t.Errorf("SequenceNumber = %d, want 42", *checkpoints[0].SequenceNumber)
+	}
+}
+
+func TestLeaseLastModifiedTime(t *testing.T) {
+	renew := metav1.NewMicroTime(time.Now().Add(-time.Minute))
+	acquire := metav1.NewMicroTime(time.Now().Add(-time.Hour))
+	created := metav1.NewTime(time.Now().Add(-24 * time.Hour))
+
+	cases := []struct {
+		name  string
+		lease *coordinationv1.Lease
+		want  time.Time
+	}{
+		{
+			name: "prefers RenewTime",
+			lease: &coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created},
+				Spec:       coordinationv1.LeaseSpec{RenewTime: &renew, AcquireTime: &acquire},
+			},
+			want: renew.Time,
+		},
+		{
+			name: "falls back to AcquireTime",
+			lease: &coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created},
+				Spec:       coordinationv1.LeaseSpec{AcquireTime: &acquire},
+			},
+			want: acquire.Time,
+		},
+		{
+			name: "falls back to CreationTimestamp",
+			lease: &coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created},
+			},
+			want: created.Time,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := leaseLastModifiedTime(c.lease); !got.Equal(c.want) {
+				t.Errorf("leaseLastModifiedTime() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestLeaseExpired(t *testing.T) {
+	durationSeconds := int32(30)
+
+	cases := []struct {
+		name  string
+		lease *coordinationv1.Lease
+		want  bool
+	}{
+		{
+			name:  "no renew time is expired",
+			lease: &coordinationv1.Lease{},
+			want:  true,
+		},
+		{
+			name: "recently renewed is not expired",
+			lease: &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{
+				RenewTime:            ptrMicroTime(time.Now()),
+				LeaseDurationSeconds: &durationSeconds,
+			}},
+			want: false,
+		},
+		{
+			name: "renewed long ago is expired",
+			lease: &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{
+				RenewTime:            ptrMicroTime(time.Now().Add(-time.Hour)),
+				LeaseDurationSeconds: &durationSeconds,
+			}},
+			want: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := leaseExpired(c.lease); got != c.want {
+				t.Errorf("leaseExpired() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func ptrMicroTime(t time.Time) *metav1.MicroTime {
+	mt := metav1.NewMicroTime(t)
+	return &mt
+}
@@ -0,0 +1,149 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common/azureauth"
+)
+
+// azureInputConfig holds the user configurable options for the azure-eventhub
+// input, shared by both the legacy and azeventhubs consumer implementations.
+type azureInputConfig struct {
+	ConnectionString    string `config:"connection_string"`
+	EventHubName        string `config:"eventhub"`
+	ConsumerGroup       string `config:"consumer_group"`
+	SAName              string `config:"storage_account"`
+	SAKey               string `config:"storage_account_key"`
+	SAContainer         string `config:"storage_account_container"`
+	OverrideEnvironment string `config:"override_environment"`
+
+	// ConsumerClient selects the underlying Event Hubs client implementation.
+	// "legacy" keeps the existing github.com/Azure/azure-event-hubs-go/v3 EPH
+	// based consumer, "azeventhubs" switches to the
+	// github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs consumer.
+	ConsumerClient string `config:"consumer_client"`
+
+	// FullyQualifiedNamespace and EventHubName identify the Event Hub when
+	// Auth is used instead of ConnectionString (e.g. "ns.servicebus.windows.net").
+	FullyQualifiedNamespace string `config:"fully_qualified_namespace"`
+
+	// Auth configures Azure AD authentication for both the Event Hubs
+	// connection and the Blob Storage checkpoint store. When Auth.Type is
+	// unset or "connection_string", ConnectionString/SAKey continue to be
+	// used and Auth is ignored. Shared with the azureeventhub output via
+	// libbeat/common/azureauth.
+	Auth azureauth.Config `config:"auth"`
+
+	// CheckpointStore selects where partition ownership and checkpoints are
+	// persisted when ConsumerClient is "azeventhubs": "blob" (the default)
+	// uses the same storage account as the legacy leaser, "kubernetes"
+	// stores them as coordination.k8s.io/v1 Leases so no storage account is
+	// required.
+	CheckpointStore string `config:"checkpoint_store"`
+
+	// Kubernetes configures the kubernetes checkpoint store.
+	Kubernetes kubernetesCheckpointStoreConfig `config:"kubernetes"`
+
+	// Stall configures the stall detector that replaces the old
+	// panic-on-idle watchdog.
+	Stall stallConfig `config:"stall"`
+}
+
+// stallConfig configures the stall detector's thresholds and the action it
+// takes once a partition is confirmed stalled.
+type stallConfig struct {
+	// IdleTimeout is how long a partition can go without being actively
+	// processed before it's considered idle.
+	IdleTimeout time.Duration `config:"idle_timeout"`
+
+	// CheckpointMaxAge is how stale a partition's last checkpoint can be
+	// before its lease is considered stalled rather than just quiet.
+	CheckpointMaxAge time.Duration `config:"checkpoint_max_age"`
+
+	// Action is taken once a partition is confirmed stalled: "reclaim"
+	// (default) releases just that partition's lease so another instance
+	// can pick it up, "restart_input" stops and restarts the whole input
+	// worker, "panic" crashes the process as the last resort.
+	Action string `config:"action"`
+}
+
+const (
+	stallActionReclaim      = "reclaim"
+	stallActionRestartInput = "restart_input"
+	stallActionPanic        = "panic"
+
+	defaultStallIdleTimeout      = 60 * time.Second
+	defaultStallCheckpointMaxAge = 30 * time.Minute
+)
+
+// effectiveIdleTimeout returns the configured idle timeout, defaulting to
+// the 60 second threshold the old watchdog used.
+func (c stallConfig) effectiveIdleTimeout() time.Duration {
+	if c.IdleTimeout <= 0 {
+		return defaultStallIdleTimeout
+	}
+	return c.IdleTimeout
+}
+
+// effectiveCheckpointMaxAge returns the configured checkpoint max age,
+// defaulting to the 30 minute threshold the old watchdog used.
+func (c stallConfig) effectiveCheckpointMaxAge() time.Duration {
+	if c.CheckpointMaxAge <= 0 {
+		return defaultStallCheckpointMaxAge
+	}
+	return c.CheckpointMaxAge
+}
+
+// effectiveAction returns the configured stall action, defaulting to
+// "reclaim" to match the old watchdog's lease-release behavior.
+func (c stallConfig) effectiveAction() string {
+	if c.Action == "" {
+		return stallActionReclaim
+	}
+	return c.Action
+}
+
+// kubernetesCheckpointStoreConfig configures the Kubernetes Lease based
+// checkpoint store.
+type kubernetesCheckpointStoreConfig struct {
+	// Namespace is the namespace Leases are created in. Defaults to the
+	// namespace the pod is running in, read from the service account.
+	Namespace string `config:"namespace"`
+
+	// LeasePrefix is prepended to the partition ID to form the Lease name.
+	LeasePrefix string `config:"lease_prefix"`
+}
+
+const (
+	checkpointStoreBlob       = "blob"
+	checkpointStoreKubernetes = "kubernetes"
+)
+
+// effectiveCheckpointStore returns the configured checkpoint store,
+// defaulting to the Azure Blob Storage backed store.
+func (c azureInputConfig) effectiveCheckpointStore() string {
+	if c.CheckpointStore == "" {
+		return checkpointStoreBlob
+	}
+	return c.CheckpointStore
+}
+
+const (
+	consumerClientLegacy      = "legacy"
+	consumerClientAzEventHubs = "azeventhubs"
+)
+
+// effectiveConsumerClient returns the configured consumer client, defaulting
+// to the legacy EPH based implementation when unset.
+func (c azureInputConfig) effectiveConsumerClient() string {
+	if c.ConsumerClient == "" {
+		return consumerClientLegacy
+	}
+	return c.ConsumerClient
+}
@@ -10,13 +10,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 	_ "unsafe"
 
 	eventhub "github.com/Azure/azure-event-hubs-go/v3"
+	eventhubAuth "github.com/Azure/azure-event-hubs-go/v3/aad"
 	"github.com/Azure/azure-event-hubs-go/v3/eph"
 	"github.com/Azure/azure-event-hubs-go/v3/storage"
-	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/go-autorest/autorest/azure"
 )
 
@@ -35,7 +34,7 @@ var environments = map[string]azure.Environment{
 // - https://docs.microsoft.com/en-us/azure/event-hubs/event-hubs-event-processor-host
 func (a *azureInput) runWithEPH() error {
 	// create a new Azure Storage Leaser / Checkpointer
-	cred, err := azblob.NewSharedKeyCredential(a.config.SAName, a.config.SAKey)
+	cred, err := a.newBlobCredential()
 	if err != nil {
 		return err
 	}
@@ -49,23 +48,27 @@ func (a *azureInput) runWithEPH() error {
 		return err
 	}
 
-	// adding a nil EventProcessorHostOption will break the code,
-	// this is why a condition is added and a.processor is assigned.
+	ephOpts := []eph.EventProcessorHostOption{eph.WithNoBanner()}
 	if a.config.ConsumerGroup != "" {
-		a.processor, err = eph.NewFromConnectionString(
-			a.workerCtx,
-			fmt.Sprintf("%s%s%s", a.config.ConnectionString, eventHubConnector, a.config.EventHubName),
-			leaserCheckpointer,
-			leaserCheckpointer,
-			eph.WithConsumerGroup(a.config.ConsumerGroup),
-			eph.WithNoBanner())
+		ephOpts = append(ephOpts, eph.WithConsumerGroup(a.config.ConsumerGroup))
+	}
+
+	if a.usesAzureADAuth() {
+		var tokenProvider eventhubAuth.TokenProvider
+		tokenProvider, err = a.newEventHubTokenProvider()
+		if err != nil {
+			a.log.Errorw("error creating event hub token provider", "error", err)
+			return err
+		}
+		a.processor, err = eph.New(a.workerCtx, a.config.FullyQualifiedNamespace, a.config.EventHubName,
+			tokenProvider, leaserCheckpointer, leaserCheckpointer, ephOpts...)
 	} else {
 		a.processor, err = eph.NewFromConnectionString(
 			a.workerCtx,
 			fmt.Sprintf("%s%s%s", a.config.ConnectionString, eventHubConnector, a.config.EventHubName),
 			leaserCheckpointer,
 			leaserCheckpointer,
-			eph.WithNoBanner())
+			ephOpts...)
 	}
 	if err != nil {
 		a.log.Errorw("error creating processor", "error", err)
@@ -113,50 +116,24 @@ func (a *azureInput) runWithEPH() error {
 		return err
 	}
 
-	// temporary workaround
-	// there is probaby some nasty bug in the azure-event-hubs-go sdk
-	// where the consumption stops but lease keep renewing
-	// so crash the process if the process doesn't do anything in 1 minute
+	// The stall detector replaces the old global zeroCount watchdog: it
+	// tracks each partition individually and only reclaims the lease of a
+	// partition that is genuinely stalled, escalating to stall.action
+	// instead of unconditionally panicking the process.
+	metrics := newStallMetrics(a.id, nil)
+	detector := newStallDetector(a.log, lf, a.config.Stall, metrics)
 	go func() {
-		zeroCount := 0
-		for {
-			select {
-			case <-watcherCtx.Done():
-				return
-			default:
-				if len(a.processor.PartitionIDsBeingProcessed()) == 0 {
-					zeroCount++
-				} else {
-					// clear the counter if there is any activity
-					zeroCount = 0
-				}
-				if zeroCount > 60 {
-					a.log.Errorw("process is idle for a while")
-					// if one process is idle for a while, then it will check for stale leases
-					leases, err := lf.GetLeases(watcherCtx)
-					if err != nil {
-						a.log.Errorw("error getting leases", "error", err)
-					} else {
-						var lastErr error
-						for _, lease := range leases {
-							// the checkpoint is not updated for a while, likely hits the bug
-							if lease.Checkpoint.EnqueueTime.Before(time.Now().Add(-30 * time.Minute)) {
-								a.log.Errorw("lease is stale, deleting", "lease", lease, "checkpoint", lease.Checkpoint)
-								if err = lf.ReleaseLease(watcherCtx, lease); err != nil {
-									a.log.Errorw("error deleting lease", "error", err)
-									lastErr = err
-								}
-							}
-						}
-						if lastErr == nil {
-							// reset the counter if no error, so that it won't repeatly check for stale leases
-							zeroCount = 0
-						}
-					}
-				}
-				time.Sleep(1 * time.Second)
+		defer metrics.Close()
+		detector.Run(watcherCtx, a.processor.PartitionIDsBeingProcessed, func(action string) {
+			switch action {
+			case stallActionRestartInput:
+				a.log.Errorw("stall detector: restarting input worker")
+				a.Stop()
+			case stallActionPanic:
+				cancelWatcher()
+				panic("azureeventhub input: stall detector escalated to panic, see preceding log entries")
 			}
-		}
+		})
 	}()
 
 	return nil
@@ -52,6 +52,14 @@ func NewLeaseFixer(credential storage.Credential, accountName, containerName str
 	}, nil
 }
 
+// PartitionIDs returns the partition IDs this leaser is responsible for,
+// without the per-partition blob Download that GetLeases performs, so
+// callers can cheaply check for idle partitions before paying for a full
+// GetLeases call.
+func (sl *LeaseFixer) PartitionIDs() []string {
+	return sl.processor.GetPartitionIDs()
+}
+
 // GetLeases gets all of the partition leases
 func (sl *LeaseFixer) GetLeases(ctx context.Context) ([]*StorageLease, error) {
 	pids := sl.processor.GetPartitionIDs()
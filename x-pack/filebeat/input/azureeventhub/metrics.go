@@ -0,0 +1,40 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"github.com/elastic/beats/v7/libbeat/monitoring/inputmon"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// stallMetrics exposes the stall detector's counters through the per-input
+// monitoring registry that inputmon.NewInputRegistry creates for the
+// input's id, the same registry other inputs report their metrics in.
+type stallMetrics struct {
+	unregister func()
+
+	partitionsIdle       *monitoring.Uint // number of partitions currently past stall.idle_timeout
+	staleLeasesReclaimed *monitoring.Uint // count of leases released because their checkpoint stalled
+	stallActions         *monitoring.Uint // count of stall.action escalations taken (reclaim/restart_input/panic)
+}
+
+func newStallMetrics(id string, optionalParent *monitoring.Registry) *stallMetrics {
+	reg, unregister := inputmon.NewInputRegistry("azureeventhub", id, optionalParent)
+	return &stallMetrics{
+		unregister:           unregister,
+		partitionsIdle:       monitoring.NewUint(reg, "partitions_idle"),
+		staleLeasesReclaimed: monitoring.NewUint(reg, "stale_leases_reclaimed"),
+		stallActions:         monitoring.NewUint(reg, "stall_actions"),
+	}
+}
+
+func (m *stallMetrics) Close() {
+	if m == nil || m.unregister == nil {
+		return
+	}
+	m.unregister()
+}
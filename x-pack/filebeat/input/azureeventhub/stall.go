@@ -0,0 +1,187 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// stallDetector replaces the old global zeroCount watchdog with per-partition
+// tracking: a partition is only acted on once it is both idle (not in
+// PartitionIDsBeingProcessed) for longer than stall.idle_timeout AND its
+// checkpoint hasn't advanced for longer than stall.checkpoint_max_age,
+// instead of releasing every lease as soon as the whole process looks quiet.
+type stallDetector struct {
+	log     partitionLogger
+	lf      *LeaseFixer
+	config  stallConfig
+	metrics *stallMetrics
+
+	mu         sync.Mutex
+	partitions map[string]*partitionState
+}
+
+// partitionState is the per-partition bookkeeping stall.* thresholds are
+// evaluated against.
+type partitionState struct {
+	owner                 string
+	lastEventTime         time.Time
+	lastCheckpointAdvance time.Time
+	lastCheckpointValue   time.Time
+}
+
+// partitionLogger is the subset of *logp.Logger the stall detector needs,
+// kept narrow so it's trivial to stub in tests.
+type partitionLogger interface {
+	Errorw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+}
+
+func newStallDetector(log partitionLogger, lf *LeaseFixer, config stallConfig, metrics *stallMetrics) *stallDetector {
+	return &stallDetector{
+		log:        log,
+		lf:         lf,
+		config:     config,
+		metrics:    metrics,
+		partitions: make(map[string]*partitionState),
+	}
+}
+
+// Run polls lease state at a quarter of the idle timeout (capped at 15s)
+// until ctx is done, reconciling per-partition activity and escalating
+// stalled partitions per stall.action. activePartitions reports the
+// partition IDs the processor currently considers active.
+func (d *stallDetector) Run(ctx context.Context, activePartitions func() []string, onEscalate func(action string)) {
+	interval := d.config.effectiveIdleTimeout() / 4
+	if interval > 15*time.Second {
+		interval = 15 * time.Second
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.tick(ctx, activePartitions(), onEscalate)
+		}
+	}
+}
+
+// stallStatus evaluates stall.idle_timeout and stall.checkpoint_max_age
+// against a partition's bookkeeping, split out of tick so the threshold
+// math can be tested without a *LeaseFixer.
+func stallStatus(now time.Time, state *partitionState, config stallConfig) (idle, stalled bool) {
+	idle = now.Sub(state.lastEventTime) > config.effectiveIdleTimeout()
+	stalled = idle && now.Sub(state.lastCheckpointAdvance) > config.effectiveCheckpointMaxAge()
+	return idle, stalled
+}
+
+// anyPartitionIdle updates each known partition's lastEventTime from
+// activeSet and reports whether any partition this leaser is responsible
+// for has gone longer than stall.idle_timeout without being processed, all
+// from in-memory state so it never has to touch Blob Storage.
+func (d *stallDetector) anyPartitionIdle(now time.Time, activeSet map[string]bool) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	idle := false
+	for _, partitionID := range d.lf.PartitionIDs() {
+		state, ok := d.partitions[partitionID]
+		if !ok {
+			state = &partitionState{lastEventTime: now, lastCheckpointAdvance: now}
+			d.partitions[partitionID] = state
+		}
+		if activeSet[partitionID] {
+			state.lastEventTime = now
+		}
+		if now.Sub(state.lastEventTime) > d.config.effectiveIdleTimeout() {
+			idle = true
+		}
+	}
+	return idle
+}
+
+func (d *stallDetector) tick(ctx context.Context, active []string, onEscalate func(action string)) {
+	activeSet := make(map[string]bool, len(active))
+	for _, id := range active {
+		activeSet[id] = true
+	}
+
+	now := time.Now()
+
+	// GetLeases does a blob Download per partition, so only pay for it once
+	// a partition actually looks idle; the common healthy-partition case is
+	// resolved from the in-memory state updated here instead.
+	if !d.anyPartitionIdle(now, activeSet) {
+		d.metrics.partitionsIdle.Set(0)
+		return
+	}
+
+	leases, err := d.lf.GetLeases(ctx)
+	if err != nil {
+		d.log.Errorw("stall detector: error listing leases", "error", err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var idleCount uint64
+	for _, lease := range leases {
+		partitionID := lease.GetPartitionID()
+		state, ok := d.partitions[partitionID]
+		if !ok {
+			state = &partitionState{lastEventTime: now, lastCheckpointAdvance: now}
+			d.partitions[partitionID] = state
+		}
+		state.owner = lease.Owner
+
+		if activeSet[partitionID] {
+			state.lastEventTime = now
+		}
+		if lease.Checkpoint != nil && lease.Checkpoint.EnqueueTime != state.lastCheckpointValue {
+			state.lastCheckpointValue = lease.Checkpoint.EnqueueTime
+			state.lastCheckpointAdvance = now
+		}
+
+		idle, stalled := stallStatus(now, state, d.config)
+		if idle {
+			idleCount++
+		}
+		if !stalled {
+			continue
+		}
+
+		d.log.Errorw("stall detector: partition stalled, reclaiming lease",
+			"partition", partitionID, "owner", state.owner,
+			"idle_for", now.Sub(state.lastEventTime), "checkpoint_age", now.Sub(state.lastCheckpointAdvance))
+
+		if err := d.lf.ReleaseLease(ctx, lease); err != nil {
+			d.log.Errorw("stall detector: error releasing stale lease", "partition", partitionID, "error", err)
+			continue
+		}
+		d.metrics.staleLeasesReclaimed.Inc()
+		delete(d.partitions, partitionID)
+
+		action := d.config.effectiveAction()
+		d.metrics.stallActions.Inc()
+		if action != stallActionReclaim {
+			d.log.Infow("stall detector: escalating stall action", "partition", partitionID, "action", action)
+			onEscalate(action)
+		}
+	}
+
+	d.metrics.partitionsIdle.Set(idleCount)
+}
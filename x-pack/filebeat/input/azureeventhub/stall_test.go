@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !aix
+
+package azureeventhub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStallStatus(t *testing.T) {
+	config := stallConfig{
+		IdleTimeout:      time.Minute,
+		CheckpointMaxAge: 10 * time.Minute,
+	}
+	now := time.Now()
+
+	cases := []struct {
+		name           string
+		lastEvent      time.Duration // how long ago relative to now
+		lastCheckpoint time.Duration
+		wantIdle       bool
+		wantStalled    bool
+	}{
+		{
+			name:           "active partition",
+			lastEvent:      0,
+			lastCheckpoint: 0,
+			wantIdle:       false,
+			wantStalled:    false,
+		},
+		{
+			name:           "idle but checkpoint still fresh",
+			lastEvent:      2 * time.Minute,
+			lastCheckpoint: time.Minute,
+			wantIdle:       true,
+			wantStalled:    false,
+		},
+		{
+			name:           "idle and checkpoint stale: stalled",
+			lastEvent:      2 * time.Minute,
+			lastCheckpoint: 15 * time.Minute,
+			wantIdle:       true,
+			wantStalled:    true,
+		},
+		{
+			name:           "checkpoint stale but partition still active: not stalled",
+			lastEvent:      0,
+			lastCheckpoint: 15 * time.Minute,
+			wantIdle:       false,
+			wantStalled:    false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state := &partitionState{
+				lastEventTime:         now.Add(-c.lastEvent),
+				lastCheckpointAdvance: now.Add(-c.lastCheckpoint),
+			}
+			idle, stalled := stallStatus(now, state, config)
+			if idle != c.wantIdle {
+				t.Errorf("idle = %v, want %v", idle, c.wantIdle)
+			}
+			if stalled != c.wantStalled {
+				t.Errorf("stalled = %v, want %v", stalled, c.wantStalled)
+			}
+		})
+	}
+}
+
+func TestStallConfigEffectiveDefaults(t *testing.T) {
+	var config stallConfig
+
+	if got := config.effectiveIdleTimeout(); got != defaultStallIdleTimeout {
+		t.Errorf("effectiveIdleTimeout() = %v, want %v", got, defaultStallIdleTimeout)
+	}
+	if got := config.effectiveCheckpointMaxAge(); got != defaultStallCheckpointMaxAge {
+		t.Errorf("effectiveCheckpointMaxAge() = %v, want %v", got, defaultStallCheckpointMaxAge)
+	}
+	if got := config.effectiveAction(); got != stallActionReclaim {
+		t.Errorf("effectiveAction() = %q, want %q", got, stallActionReclaim)
+	}
+
+	config.Action = stallActionPanic
+	if got := config.effectiveAction(); got != stallActionPanic {
+		t.Errorf("effectiveAction() = %q, want %q", got, stallActionPanic)
+	}
+}